@@ -17,17 +17,30 @@ limitations under the License.
 package options
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"math/big"
 	"net"
+	"net/http"
+	"os"
 	"path"
 	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 
 	"github.com/golang/glog"
 	"github.com/pborman/uuid"
 	"github.com/spf13/pflag"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/crypto/pkcs12"
 
 	utilnet "k8s.io/apimachinery/pkg/util/net"
 	"k8s.io/apiserver/pkg/server"
@@ -35,25 +48,311 @@ import (
 	certutil "k8s.io/client-go/util/cert"
 )
 
+const (
+	// defaultCertValidity is used for --tls-cert-validity when the flag is left
+	// unset. 14 months mirrors the validity window lnd's cert package uses for
+	// its self-signed certs, comfortably inside common CA/B Forum limits.
+	defaultCertValidity = 14 * 30 * 24 * time.Hour
+
+	// certRotationThreshold is the fraction of a self-signed certificate's total
+	// validity that must remain before the rotation loop leaves it alone. Once
+	// less than this fraction of the lifetime remains, a replacement is generated.
+	certRotationThreshold = 0.2
+
+	// certRotationCheckInterval is how often the rotation loop wakes up to look
+	// at the current certificate's remaining lifetime.
+	certRotationCheckInterval = 1 * time.Hour
+
+	// asn1EndOfTime is the last instant RFC 5280 encodes as UTCTime (years
+	// through 2049); later NotAfter values switch to GeneralizedTime.
+	// A rotated cert must never claim validity past this.
+	asn1EndOfTime = "2049-12-31T23:59:59Z"
+)
+
+// clientAuthTypes maps the --client-auth-mode flag values onto the
+// corresponding tls.ClientAuthType constants, for flag validation and
+// documentation purposes. The Go-level type actually installed into
+// tls.Config is chosen by goClientAuthType below: when a client CA pool is
+// configured, verification is delegated entirely to
+// clientCAPoolProvider.VerifyPeerCertificate (see applyServingInfoTo) instead
+// of the static pool tls.Config.ClientCAs would otherwise check, so that
+// --client-ca-file rotation takes effect without dropping connections.
+var clientAuthTypes = map[string]tls.ClientAuthType{
+	"none":               tls.NoClientCert,
+	"request":            tls.RequestClientCert,
+	"require":            tls.RequireAnyClientCert,
+	"verify-if-given":    tls.VerifyClientCertIfGiven,
+	"require-and-verify": tls.RequireAndVerifyClientCert,
+}
+
+// goClientAuthType returns the tls.ClientAuthType to install into
+// tls.Config for mode. Go's TLS stack verifies the client's chain itself
+// against tls.Config.ClientCAs for VerifyClientCertIfGiven and
+// RequireAndVerifyClientCert *before* VerifyPeerCertificate runs, using
+// whatever pool tls.Config was built with -- a snapshot that can't reflect
+// later --client-ca-file rotation. Since clientCAPoolProvider's
+// VerifyPeerCertificate hook already re-verifies the chain against the
+// live pool, downgrade those two modes to their non-verifying counterparts
+// (RequireAnyClientCert / RequestClientCert) so Go only collects the
+// certificate and the hook remains the sole source of truth for trust.
+func goClientAuthType(mode string) tls.ClientAuthType {
+	switch mode {
+	case "require-and-verify":
+		return tls.RequireAnyClientCert
+	case "verify-if-given":
+		return tls.RequestClientCert
+	default:
+		return clientAuthTypes[mode]
+	}
+}
+
+// ipAddressesValue is a pflag.Value backing a []net.IP flag that can be
+// repeated (--bind-address a --bind-address b) or given as a single
+// comma-separated list, matching the semantics of pflag's own StringSliceVar:
+// the first Set call replaces the default, subsequent calls append.
+type ipAddressesValue struct {
+	value   *[]net.IP
+	changed bool
+}
+
+func newIPAddressesValue(value *[]net.IP) *ipAddressesValue {
+	return &ipAddressesValue{value: value}
+}
+
+func (v *ipAddressesValue) String() string {
+	if v.value == nil {
+		return ""
+	}
+	addrs := make([]string, 0, len(*v.value))
+	for _, ip := range *v.value {
+		addrs = append(addrs, ip.String())
+	}
+	return strings.Join(addrs, ",")
+}
+
+func (v *ipAddressesValue) Set(val string) error {
+	parsed := make([]net.IP, 0)
+	for _, part := range strings.Split(val, ",") {
+		part = strings.TrimSpace(part)
+		ip := net.ParseIP(part)
+		if ip == nil {
+			return fmt.Errorf("invalid IP address: %q", part)
+		}
+		parsed = append(parsed, ip)
+	}
+	if !v.changed {
+		*v.value = parsed
+		v.changed = true
+	} else {
+		*v.value = append(*v.value, parsed...)
+	}
+	return nil
+}
+
+func (v *ipAddressesValue) Type() string {
+	return "ipSlice"
+}
+
 type SecureServingOptions struct {
-	BindAddress net.IP
-	BindPort    int
+	// BindAddresses are the local interfaces the secure port is served on. A
+	// single --bind-address still works as before; repeating the flag or
+	// passing a comma-separated list binds more than one address (e.g. an
+	// IPv4 and an IPv6 literal for dual-stack, or a management NIC plus a
+	// pod-network NIC), each served on its own listener in ApplyTo.
+	BindAddresses []net.IP
+	BindPort      int
 	// BindNetwork is the type of network to bind to - defaults to "tcp", accepts "tcp",
-	// "tcp4", and "tcp6".
+	// "tcp4", and "tcp6". When set, it overrides the per-address family
+	// inference ApplyTo would otherwise do for each of BindAddresses.
 	BindNetwork string
 
-	// Listener is the secure server network listener.
-	// either Listener or BindAddress/BindPort/BindNetwork is set,
-	// if Listener is set, use it and omit BindAddress/BindPort/BindNetwork.
-	Listener net.Listener
+	// Listeners are the secure server network listeners, one per entry in
+	// BindAddresses.
+	// either Listeners or BindAddresses/BindPort/BindNetwork is set,
+	// if Listeners is set, use it and omit BindAddresses/BindPort/BindNetwork.
+	Listeners []net.Listener
 
 	// ServerCert is the TLS cert info for serving secure traffic
 	ServerCert GeneratableKeyCert
 	// SNICertKeys are named CertKeys for serving secure traffic with SNI support.
 	SNICertKeys []utilflag.NamedCertKey
+	// SNICertKeysAreBundles, if true, forces every --tls-sni-cert-key CertFile
+	// to be parsed as a PKCS#12/PFX bundle regardless of its extension, for
+	// the same reason as GeneratableKeyCert.IsBundle.
+	SNICertKeysAreBundles bool
 	// CipherSuites is the list of allowed cipher suites for the server.
 	// Values are from tls package constants (https://golang.org/pkg/crypto/tls/#pkg-constants).
 	CipherSuites []string
+
+	// ACMEEnabled turns on automatic certificate issuance from an ACME CA
+	// (e.g. Let's Encrypt) in place of the self-signed default. Has no effect
+	// if --tls-cert-file/--tls-private-key-file are set, which always win.
+	ACMEEnabled bool
+	// ACMEDirectoryURL is the ACME directory endpoint to use. Defaults to the
+	// Let's Encrypt production directory when empty.
+	ACMEDirectoryURL string
+	// ACMEEmail is the contact address registered with the ACME account.
+	ACMEEmail string
+	// ACMEHosts is the whitelist of hostnames autocert is allowed to request
+	// certificates for; required whenever ACMEEnabled is set, since autocert
+	// refuses to issue for arbitrary SNI names.
+	ACMEHosts []string
+	// ACMECacheDir is the directory autocert uses to persist issued certs and
+	// account keys across restarts. Defaults to CertDirectory when empty.
+	ACMECacheDir string
+	// ACMEChallenge selects the ACME challenge type: "tls-alpn-01" (default,
+	// answered on the secure port itself) or "http-01" (answered on a plain
+	// HTTP port, see ACMEHTTPChallengePort).
+	ACMEChallenge string
+	// ACMEHTTPChallengePort is the port the http-01 challenge responder
+	// listens on when ACMEChallenge is "http-01".
+	ACMEHTTPChallengePort int
+
+	// acmeManager is the autocert.Manager constructed from the ACME* fields.
+	acmeManager *autocert.Manager
+
+	// ClientCAFile, if set, is a PEM bundle of CA certificates used to verify
+	// client certificates presented on the secure port, enabling mTLS
+	// termination without relying on the front-proxy authenticator.
+	ClientCAFile string
+	// ClientAuthMode controls whether/how client certificates are required.
+	// One of "none", "request", "require", "verify-if-given", or
+	// "require-and-verify"; see tls.ClientAuthType for the precise semantics.
+	// Defaults to "none" when ClientCAFile is unset, "require-and-verify"
+	// otherwise.
+	ClientAuthMode string
+	// ClientCAUseSystemCertPool, if true, seeds ClientCAFile's trust pool with
+	// the host's system root CAs before appending anything found in
+	// ClientCAFile. This is a distinct knob from ServerCert.UseSystemCertPool:
+	// trusting a public CA to authenticate this server's own identity is not
+	// the same as trusting it to vouch for client identities, so the two
+	// pools are never shared.
+	ClientCAUseSystemCertPool bool
+
+	// clientCAPool holds the live client CA pool so VerifyPeerCertificate can
+	// pick up --client-ca-file rotation without a restart.
+	clientCAPool    *clientCAPoolProvider
+	clientCAModTime time.Time
+
+	// CertBundlePasswordFile, if set, is a file containing the passphrase used
+	// to decrypt any PKCS#12/PFX bundle supplied as --tls-cert-file,
+	// --tls-ca-file, or --tls-sni-cert-key. Bundles are recognized by a
+	// ".p12"/".pfx" file extension, or forced by --tls-cert-bundle /
+	// --tls-sni-cert-bundle.
+	CertBundlePasswordFile string
+
+	// CertValidity is how long a self-signed certificate generated by
+	// MaybeDefaultWithSelfSignedCerts remains valid before it is proactively
+	// rotated. It has no effect on certificates supplied via --tls-cert-file
+	// or --tls-sni-cert-key, which are assumed to be managed externally.
+	CertValidity time.Duration
+
+	// selfSignedCertConfig remembers the SAN parameters used to generate the
+	// active self-signed cert, if any, so the rotation loop can regenerate an
+	// equivalent replacement. Nil when the server cert came from disk/flags.
+	selfSignedCertConfig *selfSignedCertConfig
+
+	// certProvider holds the live server certificate behind an atomic.Value so
+	// the rotation loop can swap it without disrupting in-flight handshakes.
+	certProvider *dynamicCertificateProvider
+
+	// sniCertModTimes tracks the last-seen mtime of each --tls-sni-cert-key pair
+	// so the rotation loop can detect operator-driven on-disk rotation.
+	sniCertModTimes map[string]time.Time
+}
+
+// selfSignedCertConfig captures the inputs MaybeDefaultWithSelfSignedCerts used
+// to generate the current self-signed certificate.
+type selfSignedCertConfig struct {
+	publicAddress string
+	alternateDNS  []string
+	alternateIPs  []net.IP
+}
+
+// dynamicCertificateProvider stores the currently served certificate (and SNI
+// certificate map) behind atomic.Value so callers can read a consistent
+// snapshot while the rotation loop installs replacements concurrently.
+type dynamicCertificateProvider struct {
+	cert     atomic.Value // *tls.Certificate
+	sniCerts atomic.Value // map[string]*tls.Certificate
+}
+
+func newDynamicCertificateProvider(cert *tls.Certificate, sniCerts map[string]*tls.Certificate) *dynamicCertificateProvider {
+	p := &dynamicCertificateProvider{}
+	p.cert.Store(cert)
+	p.sniCerts.Store(sniCerts)
+	return p
+}
+
+// GetCertificate implements the signature of tls.Config.GetCertificate so the
+// server package can wire it in directly once a request's SNI is known.
+func (p *dynamicCertificateProvider) GetCertificate(clientHello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if sni := p.sniCerts.Load().(map[string]*tls.Certificate); clientHello.ServerName != "" {
+		if cert, ok := sni[clientHello.ServerName]; ok {
+			return cert, nil
+		}
+	}
+	return p.cert.Load().(*tls.Certificate), nil
+}
+
+func (p *dynamicCertificateProvider) updateCert(cert *tls.Certificate) {
+	p.cert.Store(cert)
+}
+
+func (p *dynamicCertificateProvider) updateSNICert(name string, cert *tls.Certificate) {
+	existing := p.sniCerts.Load().(map[string]*tls.Certificate)
+	updated := make(map[string]*tls.Certificate, len(existing)+1)
+	for k, v := range existing {
+		updated[k] = v
+	}
+	updated[name] = cert
+	p.sniCerts.Store(updated)
+}
+
+// clientCAPoolProvider holds the client CA pool used to authenticate client
+// certificates on the secure port behind an atomic.Value, so the pool can be
+// extended/replaced at runtime (e.g. when --client-ca-file is rotated) while
+// VerifyPeerCertificate calls are in flight on other goroutines.
+type clientCAPoolProvider struct {
+	pool atomic.Value // *x509.CertPool
+}
+
+func newClientCAPoolProvider(pool *x509.CertPool) *clientCAPoolProvider {
+	p := &clientCAPoolProvider{}
+	p.pool.Store(pool)
+	return p
+}
+
+func (p *clientCAPoolProvider) update(pool *x509.CertPool) {
+	p.pool.Store(pool)
+}
+
+// VerifyPeerCertificate implements the tls.Config.VerifyPeerCertificate
+// signature, re-verifying the presented chain against the current client CA
+// pool rather than the one baked into tls.Config.ClientCAs at Config
+// construction time.
+func (p *clientCAPoolProvider) VerifyPeerCertificate(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+	if len(rawCerts) == 0 {
+		return nil
+	}
+	leaf, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		return fmt.Errorf("unable to parse client certificate: %v", err)
+	}
+	intermediates := x509.NewCertPool()
+	for _, raw := range rawCerts[1:] {
+		cert, err := x509.ParseCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("unable to parse client certificate chain: %v", err)
+		}
+		intermediates.AddCert(cert)
+	}
+	_, err = leaf.Verify(x509.VerifyOptions{
+		Roots:         p.pool.Load().(*x509.CertPool),
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	})
+	return err
 }
 
 type CertKey struct {
@@ -74,21 +373,45 @@ type GeneratableKeyCert struct {
 	// PairName is the name which will be used with CertDirectory to make a cert and key names
 	// It becomes CertDirector/PairName.crt and CertDirector/PairName.key
 	PairName string
+	// UseSystemCertPool, if true, seeds CACertFile's trust pool with the host's
+	// system root CAs before appending anything found in CACertFile, so
+	// operators can trust corporate/OS-managed CAs without maintaining a bundle.
+	UseSystemCertPool bool
+	// IsBundle, if true, forces CertKey.CertFile and CACertFile to be parsed
+	// as a PKCS#12/PFX bundle even if their extension isn't ".p12"/".pfx",
+	// e.g. for a Windows-exported bundle saved with a ".cer" or ".crt" name.
+	IsBundle bool
 }
 
 func NewSecureServingOptions() *SecureServingOptions {
 	return &SecureServingOptions{
-		BindAddress: net.ParseIP("0.0.0.0"),
-		BindPort:    443,
+		BindAddresses: []net.IP{net.ParseIP("0.0.0.0")},
+		BindPort:      443,
 		ServerCert: GeneratableKeyCert{
 			PairName:      "apiserver",
 			CertDirectory: "apiserver.local.config/certificates",
 		},
+		CertValidity: defaultCertValidity,
 	}
 }
 
+// DefaultExternalAddress chooses the first non-loopback address reachable
+// across any of BindAddresses' families, falling back to whatever the first
+// configured address resolves to if every family is loopback-only.
 func (s *SecureServingOptions) DefaultExternalAddress() (net.IP, error) {
-	return utilnet.ChooseBindAddress(s.BindAddress)
+	if len(s.BindAddresses) == 0 {
+		return nil, fmt.Errorf("no --bind-address configured")
+	}
+	for _, bindAddress := range s.BindAddresses {
+		addr, err := utilnet.ChooseBindAddress(bindAddress)
+		if err != nil {
+			continue
+		}
+		if !addr.IsLoopback() {
+			return addr, nil
+		}
+	}
+	return utilnet.ChooseBindAddress(s.BindAddresses[0])
 }
 
 func (s *SecureServingOptions) Validate() []error {
@@ -102,6 +425,34 @@ func (s *SecureServingOptions) Validate() []error {
 		errors = append(errors, fmt.Errorf("--secure-port %v must be between 0 and 65535, inclusive. 0 for turning off secure port.", s.BindPort))
 	}
 
+	if len(s.Listeners) == 0 && len(s.BindAddresses) == 0 {
+		errors = append(errors, fmt.Errorf("--bind-address must specify at least one address"))
+	}
+
+	if len(s.ClientAuthMode) != 0 {
+		if _, ok := clientAuthTypes[s.ClientAuthMode]; !ok {
+			errors = append(errors, fmt.Errorf("--client-auth-mode %q is not one of none, request, require, verify-if-given, require-and-verify", s.ClientAuthMode))
+		}
+	}
+
+	switch s.ClientAuthMode {
+	case "request", "require", "verify-if-given", "require-and-verify":
+		if len(s.ClientCAFile) == 0 {
+			errors = append(errors, fmt.Errorf("--client-ca-file must be set when --client-auth-mode is %q", s.ClientAuthMode))
+		}
+	}
+
+	if s.ACMEEnabled {
+		if len(s.ACMEHosts) == 0 {
+			errors = append(errors, fmt.Errorf("--acme-hosts must be set when --acme-enable is set"))
+		}
+		switch s.ACMEChallenge {
+		case "", "tls-alpn-01", "http-01":
+		default:
+			errors = append(errors, fmt.Errorf("--acme-challenge %q is not one of tls-alpn-01, http-01", s.ACMEChallenge))
+		}
+	}
+
 	return errors
 }
 
@@ -110,10 +461,13 @@ func (s *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 		return
 	}
 
-	fs.IPVar(&s.BindAddress, "bind-address", s.BindAddress, ""+
-		"The IP address on which to listen for the --secure-port port. The "+
+	fs.Var(newIPAddressesValue(&s.BindAddresses), "bind-address", ""+
+		"The IP address(es) on which to listen for the --secure-port port. The "+
 		"associated interface(s) must be reachable by the rest of the cluster, and by CLI/web "+
-		"clients. If blank, all interfaces will be used (0.0.0.0).")
+		"clients. If blank, all interfaces will be used (0.0.0.0). May be repeated, or given as "+
+		"a comma-separated list, to bind more than one address (e.g. an IPv4 and an IPv6 "+
+		"literal for dual-stack serving); each gets its own listener in the matching address "+
+		"family.")
 
 	fs.IntVar(&s.BindPort, "secure-port", s.BindPort, ""+
 		"The port on which to serve HTTPS with authentication and authorization. If 0, "+
@@ -137,11 +491,81 @@ func (s *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 		"Controllers. This must be a valid PEM-encoded CA bundle. Altneratively, the certificate authority "+
 		"can be appended to the certificate provided by --tls-cert-file.")
 
+	fs.BoolVar(&s.ServerCert.UseSystemCertPool, "tls-use-system-cert-pool", s.ServerCert.UseSystemCertPool, ""+
+		"Seed the --tls-ca-file trust pool with the host's system root CAs before appending "+
+		"anything found in --tls-ca-file, so corporate/OS-managed CAs are trusted without "+
+		"maintaining a separate bundle file.")
+
+	fs.BoolVar(&s.ServerCert.IsBundle, "tls-cert-bundle", s.ServerCert.IsBundle, ""+
+		"Treat --tls-cert-file and --tls-ca-file as PKCS#12/PFX bundles even if their file "+
+		"extension isn't \".p12\" or \".pfx\" (e.g. a bundle exported from Windows under a "+
+		"\".cer\" or \".crt\" name). Unnecessary when the file already ends in \".p12\"/\".pfx\".")
+
+	fs.BoolVar(&s.SNICertKeysAreBundles, "tls-sni-cert-bundle", s.SNICertKeysAreBundles, ""+
+		"Treat every --tls-sni-cert-key CertFile as a PKCS#12/PFX bundle even if its file "+
+		"extension isn't \".p12\" or \".pfx\". Unnecessary when the file already ends in "+
+		"\".p12\"/\".pfx\".")
+
 	fs.StringSliceVar(&s.CipherSuites, "tls-cipher-suites", s.CipherSuites,
 		"Comma-separated list of cipher suites for the server. "+
 			"Values are from tls package constants (https://golang.org/pkg/crypto/tls/#pkg-constants). "+
 			"If omitted, the default Go cipher suites will be used")
 
+	fs.BoolVar(&s.ACMEEnabled, "acme-enable", s.ACMEEnabled, ""+
+		"Obtain the serving certificate automatically from an ACME CA (e.g. Let's Encrypt) "+
+		"instead of generating a self-signed one. Requires --acme-hosts. Ignored if "+
+		"--tls-cert-file/--tls-private-key-file are set.")
+
+	fs.StringVar(&s.ACMEDirectoryURL, "acme-directory-url", s.ACMEDirectoryURL, ""+
+		"The ACME directory endpoint to request certificates from. Defaults to the Let's "+
+		"Encrypt production directory.")
+
+	fs.StringVar(&s.ACMEEmail, "acme-email", s.ACMEEmail, ""+
+		"Contact email address registered with the ACME account, used for expiry notices.")
+
+	fs.StringSliceVar(&s.ACMEHosts, "acme-hosts", s.ACMEHosts, ""+
+		"Comma-separated list of hostnames the ACME CA is allowed to issue certificates for. "+
+		"Required when --acme-enable is set.")
+
+	fs.StringVar(&s.ACMECacheDir, "acme-cache-dir", s.ACMECacheDir, ""+
+		"Directory used to persist ACME account keys and issued certificates across restarts. "+
+		"Defaults to --cert-dir.")
+
+	fs.StringVar(&s.ACMEChallenge, "acme-challenge", s.ACMEChallenge, ""+
+		"The ACME challenge type to answer: \"tls-alpn-01\" (default, answered on the secure "+
+		"port) or \"http-01\" (answered on --acme-http-challenge-port).")
+
+	fs.IntVar(&s.ACMEHTTPChallengePort, "acme-http-challenge-port", 80, ""+
+		"The port the http-01 challenge responder listens on. Only used when --acme-challenge=http-01.")
+
+	fs.StringVar(&s.ClientCAFile, "client-ca-file", s.ClientCAFile, ""+
+		"If set, any request presenting a client certificate signed by one of the authorities "+
+		"in this PEM bundle is authenticated with an identity corresponding to the CommonName "+
+		"of the client certificate. Required for --client-auth-mode values other than \"none\".")
+
+	fs.StringVar(&s.ClientAuthMode, "client-auth-mode", s.ClientAuthMode, ""+
+		"The policy for verifying client certificates on the secure port, one of "+
+		"\"none\", \"request\", \"require\", \"verify-if-given\", or \"require-and-verify\". "+
+		"Defaults to \"none\" if --client-ca-file is unset, or \"require-and-verify\" otherwise.")
+
+	fs.BoolVar(&s.ClientCAUseSystemCertPool, "client-ca-use-system-cert-pool", s.ClientCAUseSystemCertPool, ""+
+		"Seed the --client-ca-file trust pool with the host's system root CAs before appending "+
+		"anything found in --client-ca-file. This is separate from --tls-use-system-cert-pool: "+
+		"enabling it means any certificate issued by a public CA is accepted as a valid client "+
+		"identity, so only set this if that is actually intended.")
+
+	fs.StringVar(&s.CertBundlePasswordFile, "tls-cert-bundle-password-file", s.CertBundlePasswordFile, ""+
+		"File containing the passphrase for any PKCS#12/PFX bundle (a file ending in .p12 or "+
+		".pfx, or one forced by --tls-cert-bundle/--tls-sni-cert-bundle) supplied as "+
+		"--tls-cert-file, --tls-ca-file, or --tls-sni-cert-key. Leave unset "+
+		"if the bundle is not encrypted.")
+
+	fs.DurationVar(&s.CertValidity, "tls-cert-validity", defaultCertValidity, ""+
+		"The validity duration to request for a self-signed serving certificate. Only applies "+
+		"to certificates generated because --tls-cert-file/--tls-private-key-file were left "+
+		"unset; externally supplied certificates are never rotated by this flag. The cert is "+
+		"regenerated automatically once its remaining lifetime drops below 20%.")
+
 	fs.Var(utilflag.NewNamedCertKeyArray(&s.SNICertKeys), "tls-sni-cert-key", ""+
 		"A pair of x509 certificate and private key file paths, optionally suffixed with a list of "+
 		"domain patterns which are fully qualified domain names, possibly with prefixed wildcard "+
@@ -153,7 +577,7 @@ func (s *SecureServingOptions) AddFlags(fs *pflag.FlagSet) {
 }
 
 func (s *SecureServingOptions) AddDeprecatedFlags(fs *pflag.FlagSet) {
-	fs.IPVar(&s.BindAddress, "public-address-override", s.BindAddress,
+	fs.Var(newIPAddressesValue(&s.BindAddresses), "public-address-override",
 		"DEPRECATED: see --bind-address instead.")
 	fs.MarkDeprecated("public-address-override", "see --bind-address instead.")
 }
@@ -167,20 +591,36 @@ func (s *SecureServingOptions) ApplyTo(c *server.Config) error {
 		return nil
 	}
 
-	if s.Listener == nil {
-		var err error
-		addr := net.JoinHostPort(s.BindAddress.String(), strconv.Itoa(s.BindPort))
-		s.Listener, s.BindPort, err = CreateListener(s.BindNetwork, addr)
-		if err != nil {
-			return fmt.Errorf("failed to create listener: %v", err)
+	if len(s.Listeners) == 0 {
+		if len(s.BindAddresses) == 0 {
+			return fmt.Errorf("no --bind-address configured")
+		}
+		listeners := make([]net.Listener, 0, len(s.BindAddresses))
+		for i, bindAddress := range s.BindAddresses {
+			network := s.BindNetwork
+			if len(network) == 0 {
+				network = networkFamilyFor(bindAddress)
+			}
+			addr := net.JoinHostPort(bindAddress.String(), strconv.Itoa(s.BindPort))
+			ln, port, err := CreateListener(network, addr)
+			if err != nil {
+				return fmt.Errorf("failed to create listener for %v: %v", bindAddress, err)
+			}
+			// the first address resolves :0 to the actual port chosen by the OS;
+			// every other address then binds that same concrete port.
+			if i == 0 {
+				s.BindPort = port
+			}
+			listeners = append(listeners, ln)
 		}
+		s.Listeners = listeners
 	}
 
 	if err := s.applyServingInfoTo(c); err != nil {
 		return err
 	}
 
-	c.SecureServingInfo.Listener = s.Listener
+	c.SecureServingInfo.Listeners = s.Listeners
 
 	// create self-signed cert+key with the fake server.LoopbackClientServerNameOverride and
 	// let the server return it when the loopback client connects.
@@ -207,6 +647,10 @@ func (s *SecureServingOptions) ApplyTo(c *server.Config) error {
 		c.SecureServingInfo.SNICerts[server.LoopbackClientServerNameOverride] = &tlsCert
 	}
 
+	if (s.selfSignedCertConfig != nil && s.CertValidity > 0) || len(s.SNICertKeys) != 0 || len(s.ClientCAFile) != 0 {
+		go s.runCertRotation()
+	}
+
 	return nil
 }
 
@@ -216,29 +660,56 @@ func (s *SecureServingOptions) applyServingInfoTo(c *server.Config) error {
 	serverCertFile, serverKeyFile := s.ServerCert.CertKey.CertFile, s.ServerCert.CertKey.KeyFile
 	// load main cert
 	if len(serverCertFile) != 0 || len(serverKeyFile) != 0 {
-		tlsCert, err := tls.LoadX509KeyPair(serverCertFile, serverKeyFile)
+		tlsCert, err := s.loadX509KeyPair(serverCertFile, serverKeyFile, s.ServerCert.IsBundle)
 		if err != nil {
 			return fmt.Errorf("unable to load server certificate: %v", err)
 		}
 		secureServingInfo.Cert = &tlsCert
 	}
 
-	// optionally load CA cert
-	if len(s.ServerCert.CACertFile) != 0 {
-		pemData, err := ioutil.ReadFile(s.ServerCert.CACertFile)
-		if err != nil {
-			return fmt.Errorf("failed to read certificate authority from %q: %v", s.ServerCert.CACertFile, err)
-		}
-		block, pemData := pem.Decode(pemData)
-		if block == nil {
-			return fmt.Errorf("no certificate found in certificate authority file %q", s.ServerCert.CACertFile)
-		}
-		if block.Type != "CERTIFICATE" {
-			return fmt.Errorf("expected CERTIFICATE block in certiticate authority file %q, found: %s", s.ServerCert.CACertFile, block.Type)
-		}
-		secureServingInfo.CACert = &tls.Certificate{
-			Certificate: [][]byte{block.Bytes},
+	// optionally load CA cert, seeding from the system trust store first so
+	// operators can rely on corporate/OS-managed CAs without a bundle file
+	if len(s.ServerCert.CACertFile) != 0 || s.ServerCert.UseSystemCertPool {
+		caCertPool := systemCertPoolOrEmpty(s.ServerCert.UseSystemCertPool)
+
+		if len(s.ServerCert.CACertFile) != 0 {
+			var caCerts []*x509.Certificate
+			if isPKCS12Bundle(s.ServerCert.CACertFile, s.ServerCert.IsBundle) {
+				certs, err := s.loadPKCS12CertChain(s.ServerCert.CACertFile)
+				if err != nil {
+					return fmt.Errorf("failed to read certificate authority from %q: %v", s.ServerCert.CACertFile, err)
+				}
+				caCerts = certs
+			} else {
+				pemData, err := ioutil.ReadFile(s.ServerCert.CACertFile)
+				if err != nil {
+					return fmt.Errorf("failed to read certificate authority from %q: %v", s.ServerCert.CACertFile, err)
+				}
+				for {
+					var block *pem.Block
+					block, pemData = pem.Decode(pemData)
+					if block == nil {
+						break
+					}
+					if block.Type != "CERTIFICATE" {
+						continue
+					}
+					cert, err := x509.ParseCertificate(block.Bytes)
+					if err != nil {
+						return fmt.Errorf("invalid certificate in certificate authority file %q: %v", s.ServerCert.CACertFile, err)
+					}
+					caCerts = append(caCerts, cert)
+				}
+			}
+			if len(caCerts) == 0 {
+				return fmt.Errorf("no certificate found in certificate authority file %q", s.ServerCert.CACertFile)
+			}
+			for _, cert := range caCerts {
+				caCertPool.AddCert(cert)
+			}
 		}
+
+		secureServingInfo.CACert = caCertPool
 	}
 
 	if len(s.CipherSuites) != 0 {
@@ -249,10 +720,48 @@ func (s *SecureServingOptions) applyServingInfoTo(c *server.Config) error {
 		secureServingInfo.CipherSuites = cipherSuites
 	}
 
+	// optionally require and verify client certificates for mTLS termination
+	clientAuthMode := s.ClientAuthMode
+	if len(clientAuthMode) == 0 {
+		clientAuthMode = "none"
+		if len(s.ClientCAFile) != 0 {
+			clientAuthMode = "require-and-verify"
+		}
+	}
+	secureServingInfo.ClientAuth = clientAuthTypes[clientAuthMode]
+
+	if len(s.ClientCAFile) != 0 {
+		pemData, err := ioutil.ReadFile(s.ClientCAFile)
+		if err != nil {
+			return fmt.Errorf("failed to read client certificate authority from %q: %v", s.ClientCAFile, err)
+		}
+		clientCAPool := systemCertPoolOrEmpty(s.ClientCAUseSystemCertPool)
+		if !clientCAPool.AppendCertsFromPEM(pemData) {
+			return fmt.Errorf("no certificates found in client certificate authority file %q", s.ClientCAFile)
+		}
+		s.clientCAPool = newClientCAPoolProvider(clientCAPool)
+		if fileInfo, err := os.Stat(s.ClientCAFile); err == nil {
+			s.clientCAModTime = fileInfo.ModTime()
+		}
+		secureServingInfo.ClientCAs = clientCAPool
+		switch clientAuthMode {
+		case "verify-if-given", "require-and-verify":
+			// "request" and "require" do not verify the presented chain, so
+			// the hook is only installed for the two verifying modes.
+			secureServingInfo.VerifyPeerCertificate = s.clientCAPool.VerifyPeerCertificate
+			// only safe to downgrade to the non-verifying Go-level type once
+			// VerifyPeerCertificate is actually installed above -- otherwise a
+			// misconfigured require-and-verify/verify-if-given with no
+			// --client-ca-file would accept any client certificate as
+			// authenticated instead of failing closed.
+			secureServingInfo.ClientAuth = goClientAuthType(clientAuthMode)
+		}
+	}
+
 	// load SNI certs
 	namedTLSCerts := make([]server.NamedTLSCert, 0, len(s.SNICertKeys))
 	for _, nck := range s.SNICertKeys {
-		tlsCert, err := tls.LoadX509KeyPair(nck.CertFile, nck.KeyFile)
+		tlsCert, err := s.loadX509KeyPair(nck.CertFile, nck.KeyFile, s.SNICertKeysAreBundles)
 		namedTLSCerts = append(namedTLSCerts, server.NamedTLSCert{
 			TLSCert: tlsCert,
 			Names:   nck.Names,
@@ -267,6 +776,15 @@ func (s *SecureServingOptions) applyServingInfoTo(c *server.Config) error {
 		return err
 	}
 
+	s.certProvider = newDynamicCertificateProvider(secureServingInfo.Cert, secureServingInfo.SNICerts)
+	secureServingInfo.GetCertificate = s.certProvider.GetCertificate
+
+	if s.ACMEEnabled && secureServingInfo.Cert == nil {
+		if err := s.setUpACME(secureServingInfo); err != nil {
+			return err
+		}
+	}
+
 	c.SecureServingInfo = secureServingInfo
 	c.ReadWritePort = s.BindPort
 
@@ -282,6 +800,12 @@ func (s *SecureServingOptions) MaybeDefaultWithSelfSignedCerts(publicAddress str
 		return nil
 	}
 
+	if s.ACMEEnabled {
+		// ACME provisions and persists certificates on demand as connections
+		// arrive (see applyServingInfoTo); there is nothing to generate up front.
+		return nil
+	}
+
 	keyCert.CertFile = path.Join(s.ServerCert.CertDirectory, s.ServerCert.PairName+".crt")
 	keyCert.KeyFile = path.Join(s.ServerCert.CertDirectory, s.ServerCert.PairName+".key")
 
@@ -289,18 +813,41 @@ func (s *SecureServingOptions) MaybeDefaultWithSelfSignedCerts(publicAddress str
 	if err != nil {
 		return err
 	}
-	if !canReadCertAndKey {
-		// add either the bind address or localhost to the valid alternates
-		bindIP := s.BindAddress.String()
-		if bindIP == "0.0.0.0" {
+
+	// add either the bind addresses or localhost to the valid alternates
+	for _, bindAddress := range s.BindAddresses {
+		if bindAddress.IsUnspecified() {
 			alternateDNS = append(alternateDNS, "localhost")
 		} else {
-			alternateIPs = append(alternateIPs, s.BindAddress)
+			alternateIPs = append(alternateIPs, bindAddress)
 		}
+	}
+
+	// remember the SAN parameters regardless of whether we generate a fresh cert
+	// below, so the rotation loop can produce an equivalent replacement later.
+	s.selfSignedCertConfig = &selfSignedCertConfig{
+		publicAddress: publicAddress,
+		alternateDNS:  alternateDNS,
+		alternateIPs:  alternateIPs,
+	}
+
+	validity := s.CertValidity
+	if validity <= 0 {
+		validity = defaultCertValidity
+	}
 
-		if cert, key, err := certutil.GenerateSelfSignedCertKey(publicAddress, alternateIPs, alternateDNS); err != nil {
+	if !canReadCertAndKey {
+		if cert, key, err := generateSelfSignedCertKey(publicAddress, alternateIPs, alternateDNS, validity); err != nil {
 			return fmt.Errorf("unable to generate self signed cert: %v", err)
 		} else {
+			leaf, err := parseLeafFromPEM(cert)
+			if err != nil {
+				return err
+			}
+			if exceedsASN1EndOfTime(leaf.NotAfter) {
+				return fmt.Errorf("refusing to generate self-signed cert: NotAfter %v is past the ASN.1 end of time; lower --tls-cert-validity", leaf.NotAfter)
+			}
+
 			if err := certutil.WriteCert(keyCert.CertFile, cert); err != nil {
 				return err
 			}
@@ -315,6 +862,418 @@ func (s *SecureServingOptions) MaybeDefaultWithSelfSignedCerts(publicAddress str
 	return nil
 }
 
+// runCertRotation periodically checks the remaining lifetime of the active
+// self-signed server certificate and regenerates it once less than
+// certRotationThreshold of its validity remains. On each tick it also reloads
+// --tls-sni-cert-key pairs and --client-ca-file if they changed on disk. It
+// runs for the lifetime of the process; a server cert supplied via
+// --tls-cert-file is never regenerated.
+func (s *SecureServingOptions) runCertRotation() {
+	ticker := time.NewTicker(certRotationCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if s.selfSignedCertConfig != nil && s.CertValidity > 0 {
+			if err := s.maybeRotateSelfSignedCert(); err != nil {
+				glog.Errorf("Failed to rotate self-signed serving certificate: %v", err)
+			}
+		}
+		s.reloadChangedSNICerts()
+		if s.clientCAPool != nil {
+			if err := s.reloadChangedClientCA(); err != nil {
+				glog.Errorf("Failed to reload client CA bundle: %v", err)
+			}
+		}
+	}
+}
+
+// reloadChangedClientCA stats --client-ca-file and, if it changed since the
+// last check, reparses it and replaces the live client CA pool, so
+// VerifyPeerCertificate checks later handshakes against the new set of
+// trusted authorities without a restart.
+func (s *SecureServingOptions) reloadChangedClientCA() error {
+	info, err := os.Stat(s.ClientCAFile)
+	if err != nil {
+		return err
+	}
+	if !info.ModTime().After(s.clientCAModTime) {
+		return nil
+	}
+	pemData, err := ioutil.ReadFile(s.ClientCAFile)
+	if err != nil {
+		return err
+	}
+	pool := systemCertPoolOrEmpty(s.ClientCAUseSystemCertPool)
+	if !pool.AppendCertsFromPEM(pemData) {
+		return fmt.Errorf("no certificates found in client certificate authority file %q", s.ClientCAFile)
+	}
+	s.clientCAPool.update(pool)
+	s.clientCAModTime = info.ModTime()
+	glog.Infof("Reloaded client CA bundle %q from disk", s.ClientCAFile)
+	return nil
+}
+
+// reloadChangedSNICerts stats each --tls-sni-cert-key pair and reloads any
+// whose files changed since the last check, so operators can rotate those
+// certs on disk without restarting the apiserver.
+func (s *SecureServingOptions) reloadChangedSNICerts() {
+	if s.sniCertModTimes == nil {
+		s.sniCertModTimes = map[string]time.Time{}
+	}
+	for _, nck := range s.SNICertKeys {
+		info, err := os.Stat(nck.CertFile)
+		if err != nil {
+			glog.Errorf("Failed to stat SNI cert %q: %v", nck.CertFile, err)
+			continue
+		}
+		if last, ok := s.sniCertModTimes[nck.CertFile]; ok && !info.ModTime().After(last) {
+			continue
+		}
+		tlsCert, err := s.loadX509KeyPair(nck.CertFile, nck.KeyFile, s.SNICertKeysAreBundles)
+		if err != nil {
+			glog.Errorf("Failed to reload SNI cert %q: %v", nck.CertFile, err)
+			continue
+		}
+		certMap, err := server.GetNamedCertificateMap([]server.NamedTLSCert{{TLSCert: tlsCert, Names: nck.Names}})
+		if err != nil {
+			glog.Errorf("Failed to reload SNI cert %q: %v", nck.CertFile, err)
+			continue
+		}
+		for name, cert := range certMap {
+			s.certProvider.updateSNICert(name, cert)
+		}
+		s.sniCertModTimes[nck.CertFile] = info.ModTime()
+		glog.Infof("Reloaded SNI cert %q from disk", nck.CertFile)
+	}
+}
+
+func (s *SecureServingOptions) maybeRotateSelfSignedCert() error {
+	keyCert := &s.ServerCert.CertKey
+	leaf, err := x509.ParseCertificate(s.certProvider.cert.Load().(*tls.Certificate).Certificate[0])
+	if err != nil {
+		return fmt.Errorf("unable to parse current serving certificate: %v", err)
+	}
+
+	remaining := time.Until(leaf.NotAfter)
+	if remaining > rotationThresholdFor(s.CertValidity) {
+		return nil
+	}
+
+	cfg := s.selfSignedCertConfig
+	validity := s.CertValidity
+	if validity <= 0 {
+		validity = defaultCertValidity
+	}
+	certPem, keyPem, err := generateSelfSignedCertKey(cfg.publicAddress, cfg.alternateIPs, cfg.alternateDNS, validity)
+	if err != nil {
+		return fmt.Errorf("unable to generate replacement self-signed cert: %v", err)
+	}
+
+	newLeaf, err := parseLeafFromPEM(certPem)
+	if err != nil {
+		return err
+	}
+	if exceedsASN1EndOfTime(newLeaf.NotAfter) {
+		return fmt.Errorf("refusing to install new cert: NotAfter %v is past the ASN.1 end of time", newLeaf.NotAfter)
+	}
+	if !sameSANs(leaf, newLeaf) {
+		return fmt.Errorf("refusing to hot-swap serving cert: new SANs do not cover the previously advertised names")
+	}
+
+	newTLSCert, err := tls.X509KeyPair(certPem, keyPem)
+	if err != nil {
+		return fmt.Errorf("unable to load replacement self-signed cert: %v", err)
+	}
+
+	// the replacement is known-good at this point; keep the displaced cert on
+	// disk as <cert>.bak so an operator can recover it. The previous key is
+	// overwritten, not backed up.
+	if err := os.Rename(keyCert.CertFile, keyCert.CertFile+".bak"); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("unable to back up previous cert: %v", err)
+	}
+	if err := certutil.WriteCert(keyCert.CertFile, certPem); err != nil {
+		return err
+	}
+	if err := certutil.WriteKey(keyCert.KeyFile, keyPem); err != nil {
+		return err
+	}
+
+	// updateCert swaps the certificate behind the atomic.Value that
+	// info.GetCertificate reads from; info.Cert itself is never consulted
+	// once GetCertificate is set; writing to it directly here would race
+	// with concurrent handshakes reading it without synchronization.
+	s.certProvider.updateCert(&newTLSCert)
+	glog.Infof("Rotated self-signed serving cert (%s, %s), valid until %v", keyCert.CertFile, keyCert.KeyFile, newLeaf.NotAfter)
+
+	return nil
+}
+
+func parseLeafFromPEM(certPem []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(certPem)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM block found in generated certificate")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// rotationThresholdFor returns the remaining-lifetime cutoff below which a
+// self-signed certificate generated with the given validity is due for
+// rotation: certRotationThreshold of its total lifetime.
+func rotationThresholdFor(validity time.Duration) time.Duration {
+	return time.Duration(float64(validity) * certRotationThreshold)
+}
+
+// exceedsASN1EndOfTime reports whether t is past asn1EndOfTime, so a
+// generated or rotated certificate is never installed with a NotAfter that
+// would need GeneralizedTime rather than UTCTime.
+func exceedsASN1EndOfTime(t time.Time) bool {
+	endOfTime, err := time.Parse(time.RFC3339, asn1EndOfTime)
+	if err != nil {
+		panic(fmt.Sprintf("invalid asn1EndOfTime constant: %v", err))
+	}
+	return t.After(endOfTime)
+}
+
+// sameSANs reports whether every DNS name and IP address advertised by prev is
+// still covered by next, so a rotation never silently drops a name clients
+// depend on.
+func sameSANs(prev, next *x509.Certificate) bool {
+	nextDNS := make(map[string]bool, len(next.DNSNames))
+	for _, name := range next.DNSNames {
+		nextDNS[name] = true
+	}
+	for _, name := range prev.DNSNames {
+		if !nextDNS[name] {
+			return false
+		}
+	}
+	nextIPs := make(map[string]bool, len(next.IPAddresses))
+	for _, ip := range next.IPAddresses {
+		nextIPs[ip.String()] = true
+	}
+	for _, ip := range prev.IPAddresses {
+		if !nextIPs[ip.String()] {
+			return false
+		}
+	}
+	return true
+}
+
+// generateSelfSignedCertKey behaves like certutil.GenerateSelfSignedCertKey,
+// but additionally honors validity instead of the fixed one-year/ten-year
+// (leaf/CA) lifetimes that package hard-codes, so --tls-cert-validity and the
+// rotation loop actually control how long a generated cert lasts.
+func generateSelfSignedCertKey(host string, alternateIPs []net.IP, alternateDNS []string, validity time.Duration) ([]byte, []byte, error) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to generate private key: %v", err)
+	}
+
+	serialNumberLimit := new(big.Int).Lsh(big.NewInt(1), 128)
+	serialNumber, err := rand.Int(rand.Reader, serialNumberLimit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to generate serial number: %v", err)
+	}
+
+	notBefore := time.Now()
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: host, Organization: []string{"apiserver"}},
+		NotBefore:             notBefore,
+		NotAfter:              notBefore.Add(validity),
+		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		template.IPAddresses = append(template.IPAddresses, ip)
+	} else {
+		template.DNSNames = append(template.DNSNames, host)
+	}
+	template.IPAddresses = append(template.IPAddresses, alternateIPs...)
+	template.DNSNames = append(template.DNSNames, alternateDNS...)
+
+	derBytes, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to create certificate: %v", err)
+	}
+
+	certBuffer := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: derBytes})
+	keyBuffer := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(priv)})
+
+	return certBuffer, keyBuffer, nil
+}
+
+// systemCertPoolOrEmpty returns a copy of the host's system root CA pool when
+// useSystemPool is set, falling back to an empty pool (with a warning) on
+// platforms where Go cannot load one, e.g. Windows or pre-1.7 Go runtimes.
+func systemCertPoolOrEmpty(useSystemPool bool) *x509.CertPool {
+	if !useSystemPool {
+		return x509.NewCertPool()
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		glog.Warningf("Unable to load the system certificate pool, falling back to an empty one: %v", err)
+		return x509.NewCertPool()
+	}
+	return pool
+}
+
+// isPKCS12Bundle reports whether certFile should be parsed as a PKCS#12/PFX
+// bundle (a Java keystore export, a Windows certificate export, etc) rather
+// than a PEM file: either because forced (--tls-cert-bundle/
+// --tls-sni-cert-bundle, for bundles saved under a non-standard extension) or
+// because its extension is ".p12"/".pfx".
+func isPKCS12Bundle(certFile string, forced bool) bool {
+	if forced {
+		return true
+	}
+	switch path.Ext(certFile) {
+	case ".p12", ".pfx":
+		return true
+	default:
+		return false
+	}
+}
+
+// bundlePassword reads the passphrase for a PKCS#12 bundle from
+// CertBundlePasswordFile, returning an empty passphrase if none was set.
+func (s *SecureServingOptions) bundlePassword() (string, error) {
+	if len(s.CertBundlePasswordFile) == 0 {
+		return "", nil
+	}
+	password, err := ioutil.ReadFile(s.CertBundlePasswordFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read %q: %v", s.CertBundlePasswordFile, err)
+	}
+	return strings.TrimSpace(string(password)), nil
+}
+
+// loadX509KeyPair loads certFile/keyFile as a PEM key pair, or as a PKCS#12
+// bundle when certFile has a .p12/.pfx extension or forceBundle is set, in
+// which case keyFile is ignored (the bundle carries both the chain and the
+// private key).
+func (s *SecureServingOptions) loadX509KeyPair(certFile, keyFile string, forceBundle bool) (tls.Certificate, error) {
+	if !isPKCS12Bundle(certFile, forceBundle) {
+		return tls.LoadX509KeyPair(certFile, keyFile)
+	}
+
+	password, err := s.bundlePassword()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	bundle, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to read PKCS#12 bundle %q: %v", certFile, err)
+	}
+	privateKey, leaf, caCerts, err := pkcs12.DecodeChain(bundle, password)
+	if err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to decode PKCS#12 bundle %q: %v", certFile, err)
+	}
+	tlsCert := tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  privateKey,
+		Leaf:        leaf,
+	}
+	for _, caCert := range caCerts {
+		tlsCert.Certificate = append(tlsCert.Certificate, caCert.Raw)
+	}
+	return tlsCert, nil
+}
+
+// loadPKCS12CertChain decodes every certificate in a PKCS#12 bundle used as a
+// certificate authority file, without requiring a private key to be present.
+// Most real-world CA truststores (Java/Windows exports of trust anchors) carry
+// no private key at all, so DecodeChain's single-key-bag requirement is tried
+// first and, on failure, falls back to DecodeTrustStore, which expects only
+// certificate bags.
+func (s *SecureServingOptions) loadPKCS12CertChain(bundleFile string) ([]*x509.Certificate, error) {
+	password, err := s.bundlePassword()
+	if err != nil {
+		return nil, err
+	}
+	bundle, err := ioutil.ReadFile(bundleFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PKCS#12 bundle %q: %v", bundleFile, err)
+	}
+	if _, leaf, caCerts, err := pkcs12.DecodeChain(bundle, password); err == nil {
+		return append([]*x509.Certificate{leaf}, caCerts...), nil
+	}
+	certs, err := pkcs12.DecodeTrustStore(bundle, password)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode PKCS#12 bundle %q as either a certificate chain or a CA-only trust store: %v", bundleFile, err)
+	}
+	return certs, nil
+}
+
+// setUpACME builds the autocert.Manager described by the ACME* fields and
+// wires its certificate issuance into secureServingInfo. Names in the
+// existing SNI cert map are served from it; every other name goes to the
+// ACME manager.
+func (s *SecureServingOptions) setUpACME(secureServingInfo *server.SecureServingInfo) error {
+	cacheDir := s.ACMECacheDir
+	if len(cacheDir) == 0 {
+		cacheDir = s.ServerCert.CertDirectory
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(cacheDir),
+		HostPolicy: autocert.HostWhitelist(s.ACMEHosts...),
+		Email:      s.ACMEEmail,
+		// RenewBefore is left at its zero value, which autocert documents as
+		// defaulting to 30 days before expiry -- CertValidity/
+		// certRotationThreshold describe our own self-signed-cert rotation
+		// policy and don't apply to ACME-issued certs, whose ~90-day lifetime
+		// is set by the CA.
+	}
+	if len(s.ACMEDirectoryURL) != 0 {
+		manager.Client = &acme.Client{DirectoryURL: s.ACMEDirectoryURL}
+	}
+	s.acmeManager = manager
+
+	// fall back to the ACME manager only for names the configured SNI certs
+	// don't cover, so an operator-supplied --tls-sni-cert-key always wins.
+	secureServingInfo.GetCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		if hello.ServerName != "" {
+			if cert, ok := s.certProvider.sniCerts.Load().(map[string]*tls.Certificate)[hello.ServerName]; ok {
+				return cert, nil
+			}
+		}
+		return manager.GetCertificate(hello)
+	}
+
+	switch s.ACMEChallenge {
+	case "http-01":
+		addr := net.JoinHostPort("", strconv.Itoa(s.ACMEHTTPChallengePort))
+		ln, _, err := CreateListener("tcp", addr)
+		if err != nil {
+			return fmt.Errorf("failed to create ACME http-01 challenge listener: %v", err)
+		}
+		go func() {
+			if err := http.Serve(ln, manager.HTTPHandler(nil)); err != nil {
+				glog.Errorf("ACME http-01 challenge responder exited: %v", err)
+			}
+		}()
+	default:
+		// tls-alpn-01 is answered on the secure port itself; advertise support for it.
+		secureServingInfo.NextProtos = append(secureServingInfo.NextProtos, acme.ALPNProto)
+	}
+
+	return nil
+}
+
+// networkFamilyFor infers the listener network ("tcp4" or "tcp6") from a bind
+// address's family, so dual-stack callers don't have to set --bind-network
+// themselves when passing an IPv4 and an IPv6 literal.
+func networkFamilyFor(bindAddress net.IP) string {
+	if bindAddress.To4() != nil {
+		return "tcp4"
+	}
+	return "tcp6"
+}
+
 func CreateListener(network, addr string) (net.Listener, int, error) {
 	if len(network) == 0 {
 		network = "tcp"
@@ -0,0 +1,259 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package options
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestSameSANs(t *testing.T) {
+	cert := func(dns []string, ips []string) *x509.Certificate {
+		c := &x509.Certificate{DNSNames: dns}
+		for _, ip := range ips {
+			c.IPAddresses = append(c.IPAddresses, net.ParseIP(ip))
+		}
+		return c
+	}
+
+	cases := []struct {
+		name string
+		prev *x509.Certificate
+		next *x509.Certificate
+		want bool
+	}{
+		{
+			name: "identical",
+			prev: cert([]string{"example.com"}, []string{"10.0.0.1"}),
+			next: cert([]string{"example.com"}, []string{"10.0.0.1"}),
+			want: true,
+		},
+		{
+			name: "next is a superset",
+			prev: cert([]string{"example.com"}, []string{"10.0.0.1"}),
+			next: cert([]string{"example.com", "extra.example.com"}, []string{"10.0.0.1", "10.0.0.2"}),
+			want: true,
+		},
+		{
+			name: "next drops a DNS name",
+			prev: cert([]string{"example.com", "extra.example.com"}, nil),
+			next: cert([]string{"example.com"}, nil),
+			want: false,
+		},
+		{
+			name: "next drops an IP",
+			prev: cert(nil, []string{"10.0.0.1", "10.0.0.2"}),
+			next: cert(nil, []string{"10.0.0.1"}),
+			want: false,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := sameSANs(c.prev, c.next); got != c.want {
+				t.Errorf("sameSANs() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestGoClientAuthType(t *testing.T) {
+	cases := []struct {
+		mode string
+		want tls.ClientAuthType
+	}{
+		{mode: "none", want: tls.NoClientCert},
+		{mode: "request", want: tls.RequestClientCert},
+		{mode: "require", want: tls.RequireAnyClientCert},
+		// verify-if-given/require-and-verify are downgraded to their
+		// non-verifying counterparts: clientCAPoolProvider.VerifyPeerCertificate
+		// is the sole source of truth for trust once it's installed.
+		{mode: "verify-if-given", want: tls.RequestClientCert},
+		{mode: "require-and-verify", want: tls.RequireAnyClientCert},
+	}
+
+	for _, c := range cases {
+		if got := goClientAuthType(c.mode); got != c.want {
+			t.Errorf("goClientAuthType(%q) = %v, want %v", c.mode, got, c.want)
+		}
+	}
+}
+
+func TestExceedsASN1EndOfTime(t *testing.T) {
+	endOfTime, err := time.Parse(time.RFC3339, asn1EndOfTime)
+	if err != nil {
+		t.Fatalf("failed to parse asn1EndOfTime: %v", err)
+	}
+
+	if exceedsASN1EndOfTime(endOfTime.Add(-time.Second)) {
+		t.Errorf("exceedsASN1EndOfTime() = true for a time before the cutoff")
+	}
+	if !exceedsASN1EndOfTime(endOfTime.Add(time.Second)) {
+		t.Errorf("exceedsASN1EndOfTime() = false for a time after the cutoff")
+	}
+}
+
+func TestRotationThresholdFor(t *testing.T) {
+	validity := 100 * 24 * time.Hour
+	got := rotationThresholdFor(validity)
+	want := time.Duration(float64(validity) * certRotationThreshold)
+	if got != want {
+		t.Errorf("rotationThresholdFor(%v) = %v, want %v", validity, got, want)
+	}
+
+	// a cert with less than rotationThresholdFor(validity) remaining should
+	// be considered due for rotation by the caller's own comparison.
+	remaining := got - time.Hour
+	if remaining > rotationThresholdFor(validity) {
+		t.Errorf("expected %v to fall within the rotation window for validity %v", remaining, validity)
+	}
+}
+
+func TestIsPKCS12Bundle(t *testing.T) {
+	cases := []struct {
+		certFile string
+		forced   bool
+		want     bool
+	}{
+		{certFile: "server.crt", forced: false, want: false},
+		{certFile: "server.p12", forced: false, want: true},
+		{certFile: "server.pfx", forced: false, want: true},
+		{certFile: "server.crt", forced: true, want: true},
+	}
+
+	for _, c := range cases {
+		if got := isPKCS12Bundle(c.certFile, c.forced); got != c.want {
+			t.Errorf("isPKCS12Bundle(%q, %v) = %v, want %v", c.certFile, c.forced, got, c.want)
+		}
+	}
+}
+
+func TestNetworkFamilyFor(t *testing.T) {
+	cases := []struct {
+		address string
+		want    string
+	}{
+		{address: "0.0.0.0", want: "tcp4"},
+		{address: "127.0.0.1", want: "tcp4"},
+		{address: "::", want: "tcp6"},
+		{address: "::1", want: "tcp6"},
+	}
+
+	for _, c := range cases {
+		ip := net.ParseIP(c.address)
+		if ip == nil {
+			t.Fatalf("invalid test address %q", c.address)
+		}
+		if got := networkFamilyFor(ip); got != c.want {
+			t.Errorf("networkFamilyFor(%v) = %q, want %q", ip, got, c.want)
+		}
+	}
+}
+
+func TestCreateListenerReusesPortAcrossAddresses(t *testing.T) {
+	ln1, port, err := CreateListener("tcp4", net.JoinHostPort("127.0.0.1", "0"))
+	if err != nil {
+		t.Fatalf("CreateListener: %v", err)
+	}
+	defer ln1.Close()
+	if port == 0 {
+		t.Fatalf("CreateListener did not resolve :0 to a concrete port")
+	}
+
+	ln2, port2, err := CreateListener("tcp6", net.JoinHostPort("::1", strconv.Itoa(port)))
+	if err != nil {
+		t.Fatalf("CreateListener on second address with resolved port %d: %v", port, err)
+	}
+	defer ln2.Close()
+	if port2 != port {
+		t.Errorf("expected second listener to reuse port %d, got %d", port, port2)
+	}
+}
+
+func TestMaybeRotateSelfSignedCertRotatesOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "apiserver.crt")
+	keyFile := filepath.Join(dir, "apiserver.key")
+
+	// Generate an already-expired cert so maybeRotateSelfSignedCert is
+	// guaranteed to see it as due for rotation.
+	certPem, keyPem, err := generateSelfSignedCertKey("example.com", nil, nil, -time.Hour)
+	if err != nil {
+		t.Fatalf("generateSelfSignedCertKey: %v", err)
+	}
+	if err := ioutil.WriteFile(certFile, certPem, 0644); err != nil {
+		t.Fatalf("WriteFile(certFile): %v", err)
+	}
+	if err := ioutil.WriteFile(keyFile, keyPem, 0600); err != nil {
+		t.Fatalf("WriteFile(keyFile): %v", err)
+	}
+
+	initialCert, err := tls.X509KeyPair(certPem, keyPem)
+	if err != nil {
+		t.Fatalf("tls.X509KeyPair: %v", err)
+	}
+	initialLeaf, err := x509.ParseCertificate(initialCert.Certificate[0])
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate: %v", err)
+	}
+	initialCert.Leaf = initialLeaf
+
+	s := &SecureServingOptions{
+		CertValidity: 30 * 24 * time.Hour,
+		selfSignedCertConfig: &selfSignedCertConfig{
+			publicAddress: "example.com",
+		},
+		certProvider: newDynamicCertificateProvider(&initialCert, map[string]*tls.Certificate{}),
+	}
+	s.ServerCert.CertKey = CertKey{CertFile: certFile, KeyFile: keyFile}
+
+	if err := s.maybeRotateSelfSignedCert(); err != nil {
+		t.Fatalf("maybeRotateSelfSignedCert: %v", err)
+	}
+
+	if _, err := os.Stat(certFile + ".bak"); err != nil {
+		t.Errorf("expected previous cert to be backed up at %q: %v", certFile+".bak", err)
+	}
+
+	rotatedCert := s.certProvider.cert.Load().(*tls.Certificate)
+	rotatedLeaf, err := x509.ParseCertificate(rotatedCert.Certificate[0])
+	if err != nil {
+		t.Fatalf("x509.ParseCertificate(rotated): %v", err)
+	}
+	if !rotatedLeaf.NotAfter.After(initialLeaf.NotAfter) {
+		t.Errorf("expected rotated cert NotAfter %v to be after the expired cert's NotAfter %v", rotatedLeaf.NotAfter, initialLeaf.NotAfter)
+	}
+
+	onDiskCertPem, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		t.Fatalf("ReadFile(certFile): %v", err)
+	}
+	onDiskLeaf, err := parseLeafFromPEM(onDiskCertPem)
+	if err != nil {
+		t.Fatalf("parseLeafFromPEM: %v", err)
+	}
+	if !onDiskLeaf.NotAfter.Equal(rotatedLeaf.NotAfter) {
+		t.Errorf("on-disk cert NotAfter %v does not match the in-memory rotated cert's NotAfter %v", onDiskLeaf.NotAfter, rotatedLeaf.NotAfter)
+	}
+}
@@ -0,0 +1,224 @@
+/*
+Copyright 2016 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+
+	restclient "k8s.io/client-go/rest"
+)
+
+// LoopbackClientServerNameOverride is a name that can be used for the TLS
+// serving name of the loopback connection, so that it can be reached from
+// within the server without relying on the externally advertised address.
+const LoopbackClientServerNameOverride = "apiserver-loopback-client"
+
+// Config holds the configuration shared across the generic apiserver,
+// assembled from the various *Options' ApplyTo methods (e.g.
+// SecureServingOptions.ApplyTo).
+type Config struct {
+	// SecureServingInfo describes how to serve HTTPS with an optional
+	// loopback connection.
+	SecureServingInfo *SecureServingInfo
+
+	// LoopbackClientConfig is a config for a privileged loopback connection
+	// to the apiserver.
+	LoopbackClientConfig *restclient.Config
+
+	// ReadWritePort is the port reported in Location headers, etc.
+	// SecureServingOptions.ApplyTo sets it to BindPort, which for listeners it
+	// creates itself is the port they bound.
+	ReadWritePort int
+}
+
+// NamedTLSCert pairs a certificate with the set of names it should be served
+// for.
+type NamedTLSCert struct {
+	TLSCert tls.Certificate
+
+	// Names is an explicit list of domain patterns this certificate should
+	// answer for. If empty, the names are extracted from the certificate
+	// itself (its DNS SANs, or its CommonName if it has none).
+	Names []string
+}
+
+// SecureServingInfo holds the TLS serving configuration built by
+// SecureServingOptions.ApplyTo.
+type SecureServingInfo struct {
+	// Listener is kept for callers that only ever bind a single address.
+	// New code should prefer Listeners.
+	Listener net.Listener
+	// Listeners are the secure server network listeners to accept
+	// connections on, one per configured bind address.
+	Listeners []net.Listener
+
+	// Cert is the default server certificate, used whenever GetCertificate is
+	// nil and no SNICerts entry matches the client's requested name.
+	Cert *tls.Certificate
+	// SNICerts maps configured SNI names to the certificate to serve for
+	// them.
+	SNICerts map[string]*tls.Certificate
+	// GetCertificate, if set, is used in place of Cert/SNICerts on every
+	// handshake, e.g. so a certificate rotation loop or an ACME manager can
+	// serve a certificate without restarting the server.
+	GetCertificate func(*tls.ClientHelloInfo) (*tls.Certificate, error)
+	// CipherSuites is the list of allowed cipher suites for the server.
+	CipherSuites []uint16
+	// NextProtos is the list of application protocols advertised via ALPN.
+	// Defaults to ["h2", "http/1.1"] when empty.
+	NextProtos []string
+
+	// CACert is an optional certificate authority bundle returned to
+	// admission controllers and other in-cluster consumers that need to
+	// validate this server's identity out of band.
+	CACert *x509.CertPool
+
+	// ClientCAs, if set, is the pool used by the Go TLS stack itself to
+	// verify client certificates according to ClientAuth. Rotating the pool
+	// this field was built from takes effect only for new connections; see
+	// VerifyPeerCertificate for a pool that can rotate live connections too.
+	ClientCAs *x509.CertPool
+	// ClientAuth is the policy for requesting/verifying client certificates.
+	ClientAuth tls.ClientAuthType
+	// VerifyPeerCertificate, if set, is called by the Go TLS stack after its
+	// own verification (if any) completes, letting callers enforce a client
+	// CA pool that can be swapped at runtime without restarting listeners.
+	VerifyPeerCertificate func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error
+}
+
+func (s *SecureServingInfo) listeners() []net.Listener {
+	if len(s.Listeners) != 0 {
+		return s.Listeners
+	}
+	if s.Listener != nil {
+		return []net.Listener{s.Listener}
+	}
+	return nil
+}
+
+// tlsConfig builds the tls.Config this server listens with.
+func (s *SecureServingInfo) tlsConfig() *tls.Config {
+	nextProtos := s.NextProtos
+	if len(nextProtos) == 0 {
+		nextProtos = []string{"h2", "http/1.1"}
+	}
+
+	getCertificate := s.GetCertificate
+	if getCertificate == nil {
+		getCertificate = func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if hello.ServerName != "" {
+				if cert, ok := s.SNICerts[hello.ServerName]; ok {
+					return cert, nil
+				}
+			}
+			return s.Cert, nil
+		}
+	}
+
+	return &tls.Config{
+		NextProtos:            nextProtos,
+		CipherSuites:          s.CipherSuites,
+		GetCertificate:        getCertificate,
+		ClientCAs:             s.ClientCAs,
+		ClientAuth:            s.ClientAuth,
+		VerifyPeerCertificate: s.VerifyPeerCertificate,
+	}
+}
+
+// Serve runs the HTTPS server described by this SecureServingInfo, serving
+// handler on every configured listener, and blocks until the first listener
+// stops serving (e.g. because it was closed), returning its error. The other
+// listeners are left serving.
+func (s *SecureServingInfo) Serve(handler http.Handler) error {
+	listeners := s.listeners()
+	if len(listeners) == 0 {
+		return fmt.Errorf("no listener configured for secure serving")
+	}
+
+	tlsConfig := s.tlsConfig()
+	errs := make(chan error, len(listeners))
+	for _, ln := range listeners {
+		ln := ln
+		go func() {
+			httpServer := &http.Server{Handler: handler, TLSConfig: tlsConfig}
+			errs <- httpServer.ServeTLS(ln, "", "")
+		}()
+	}
+
+	return <-errs
+}
+
+// NewLoopbackClientConfig returns a restclient.Config that talks to this
+// server's secure port via the loopback SNI name, authenticating with token.
+func (s *SecureServingInfo) NewLoopbackClientConfig(token string, caCert []byte) (*restclient.Config, error) {
+	listeners := s.listeners()
+	if len(listeners) == 0 {
+		return nil, fmt.Errorf("no listener to build a loopback client config from")
+	}
+
+	_, port, err := net.SplitHostPort(listeners[0].Addr().String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid listen address: %v", err)
+	}
+
+	return &restclient.Config{
+		Host:        "https://" + net.JoinHostPort("localhost", port),
+		BearerToken: token,
+		TLSClientConfig: restclient.TLSClientConfig{
+			ServerName: LoopbackClientServerNameOverride,
+			CAData:     caCert,
+		},
+	}, nil
+}
+
+// GetNamedCertificateMap builds the map of SNI name to certificate used to
+// serve --tls-sni-cert-key. For each cert, names are taken from Names if set,
+// otherwise parsed from the certificate's DNS SANs, or its CommonName if it
+// has no DNS SANs.
+func GetNamedCertificateMap(namedTLSCerts []NamedTLSCert) (map[string]*tls.Certificate, error) {
+	certMap := map[string]*tls.Certificate{}
+	for i := range namedTLSCerts {
+		namedCert := &namedTLSCerts[i]
+		names := namedCert.Names
+		if len(names) == 0 {
+			leaf := namedCert.TLSCert.Leaf
+			if leaf == nil {
+				var err error
+				if len(namedCert.TLSCert.Certificate) == 0 {
+					return nil, fmt.Errorf("no certificate data to determine names from")
+				}
+				leaf, err = x509.ParseCertificate(namedCert.TLSCert.Certificate[0])
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse certificate to determine names: %v", err)
+				}
+			}
+			if len(leaf.DNSNames) != 0 {
+				names = leaf.DNSNames
+			} else if len(leaf.Subject.CommonName) != 0 {
+				names = []string{leaf.Subject.CommonName}
+			}
+		}
+		for _, name := range names {
+			certMap[name] = &namedCert.TLSCert
+		}
+	}
+	return certMap, nil
+}